@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/xml"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type atomFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	Xmlns string `xml:"xmlns,attr"`
+	Title string `xml:"title"`
+	ID string `xml:"id"`
+	Updated string `xml:"updated"`
+	Link atomLink `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	Title string `xml:"title"`
+	ID string `xml:"id"`
+	Updated string `xml:"updated"`
+	Author atomAuthor `xml:"author"`
+	Content atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// RecentChangesFeed builds an Atom 1.0 feed of the wiki's most recent
+// changes. host is used to build the feed's self link and the tag: URIs
+// that identify each entry, so those identifiers stay stable even if the
+// corresponding page is later renamed.
+func RecentChangesFeed(wiki *Wiki, host string, limit int) (atomFeed, error) {
+	changes, err := wiki.RecentChanges(limit)
+	if err != nil { return atomFeed{}, err }
+	feed := atomFeed{
+		Xmlns: "http://www.w3.org/2005/Atom",
+		Title: "Recent changes",
+		ID: "tag:" + host + ":recent-changes",
+		Link: atomLink{Href: "http://" + host + "/feed.atom", Rel: "self"},
+	}
+	if len(changes) > 0 {
+		feed.Updated = changes[0].Timestamp.Format(time.RFC3339)
+	}
+	for _, c := range changes {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title: c.Message,
+			ID: "tag:" + host + "," + strconv.Itoa(c.Timestamp.Year()) + ":" + c.SHA,
+			Updated: c.Timestamp.Format(time.RFC3339),
+			Author: atomAuthor{Name: c.Author},
+			Content: atomContent{Type: "html", Body: changeContent(wiki, c)},
+		})
+	}
+	return feed, nil
+}
+
+// changeContent renders the body of an Atom entry for a single change: the
+// diff for the first file the commit lists, falling back to that file's
+// current rendered body when the diff fails (as it does for a commit with no
+// parent) or is empty.
+func changeContent(wiki *Wiki, c Change) string {
+	if len(c.Paths) == 0 { return "" }
+	path := c.Paths[0]
+	if diff, err := wiki.Diff(path, c.SHA+"^", c.SHA); err == nil && strings.TrimSpace(diff) != "" {
+		return "<pre>" + template.HTMLEscapeString(diff) + "</pre>"
+	}
+	pg, err := wiki.GetPage(path)
+	if err != nil { return "" }
+	pg.ProcessMarkdown()
+	return string(pg.Body)
+}
+
+func ShowFeed (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
+	return func (w http.ResponseWriter, r *http.Request) {
+		feed, err := RecentChangesFeed(wiki, r.Host, 30)
+		if err != nil { return }
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		w.Write([]byte(xml.Header))
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "  ")
+		enc.Encode(feed)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchHit is a single ranked result from Wiki.Search.
+type SearchHit struct {
+	Path string
+	Title string
+	Score float64
+	Snippet template.HTML
+}
+
+// titleBoost is added to a page's score for every query term that also
+// appears in its title, so a page about "gardening" outranks one that
+// merely mentions gardening in passing.
+const titleBoost = 5.0
+
+// snippetRadius is how many characters of context Search shows on each side
+// of the first matched term, giving a ~160 character snippet.
+const snippetRadius = 80
+
+// searchIndex is an in-memory inverted index over a single wiki: token ->
+// page path -> token positions within that page, plus enough bookkeeping to
+// notice when a page has changed on disk and needs re-indexing.
+type searchIndex struct {
+	mu sync.Mutex
+	postings map[string]map[string][]int
+	content map[string]string
+	mtimes map[string]time.Time
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		postings: map[string]map[string][]int{},
+		content: map[string]string{},
+		mtimes: map[string]time.Time{},
+	}
+}
+
+// searchIndexes holds one searchIndex per wiki path, mirroring the
+// per-path lock pool in wiki.go so every *Wiki pointing at the same
+// directory shares a single index.
+var searchIndexesMu sync.Mutex
+var searchIndexes = map[string]*searchIndex{}
+
+func (w *Wiki) index() *searchIndex {
+	searchIndexesMu.Lock()
+	defer searchIndexesMu.Unlock()
+	idx, ok := searchIndexes[w.Path]
+	if !ok {
+		idx = newSearchIndex()
+		searchIndexes[w.Path] = idx
+	}
+	return idx
+}
+
+// BuildSearchIndex walks the whole wiki tree and populates the search index.
+// Search refreshes incrementally on every call, so calling this explicitly
+// is optional, but doing it once at startup avoids paying for the initial
+// full walk on the first search request.
+func (w *Wiki) BuildSearchIndex() error {
+	idx := w.index()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.refresh(w)
+}
+
+// Search tokenizes query, re-indexes any page that has changed since the
+// last search, and returns up to limit hits ranked by term frequency with a
+// boost for matches in the page title.
+func (w *Wiki) Search(query string, limit int) ([]SearchHit, error) {
+	idx := w.index()
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if err := idx.refresh(w); err != nil { return nil, err }
+
+	terms := tokenize(query)
+	scores := map[string]float64{}
+	for _, term := range terms {
+		for path, positions := range idx.postings[term] {
+			scores[path] += float64(len(positions))
+		}
+	}
+	for path := range scores {
+		title := strings.ToLower(WebPathToUserTitle(strings.TrimSuffix(path, canonicalExt)))
+		for _, term := range terms {
+			if term != "" && strings.Contains(title, term) { scores[path] += titleBoost }
+		}
+	}
+
+	paths := make([]string, 0, len(scores))
+	for path := range scores { paths = append(paths, path) }
+	sort.Slice(paths, func(i, j int) bool {
+		if scores[paths[i]] != scores[paths[j]] { return scores[paths[i]] > scores[paths[j]] }
+		return paths[i] < paths[j]
+	})
+	if limit > 0 && len(paths) > limit { paths = paths[:limit] }
+
+	res := make([]SearchHit, 0, len(paths))
+	for _, path := range paths {
+		title := WebPathToUserTitle(strings.TrimSuffix(path, canonicalExt))
+		res = append(res, SearchHit{
+			Path: UserTitleToWebPath(title),
+			Title: title,
+			Score: scores[path],
+			Snippet: snippet(idx.content[path], terms),
+		})
+	}
+	return res, nil
+}
+
+// refresh walks the wiki tree, skipping .git and hidden files, re-indexing
+// any page whose mtime has changed and dropping pages that no longer exist.
+// Callers must hold idx.mu.
+func (idx *searchIndex) refresh(w *Wiki) error {
+	seen := map[string]bool{}
+	err := filepath.Walk(w.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil { return err }
+		if p == w.Path { return nil }
+		base := filepath.Base(p)
+		if strings.HasPrefix(base, ".") {
+			if info.IsDir() { return filepath.SkipDir }
+			return nil
+		}
+		if info.IsDir() || !strings.HasSuffix(base, canonicalExt) { return nil }
+		rel, err := filepath.Rel(w.Path, p)
+		if err != nil { return err }
+		seen[rel] = true
+		if mt, ok := idx.mtimes[rel]; ok && mt.Equal(info.ModTime()) { return nil }
+		body, err := ioutil.ReadFile(p)
+		if err != nil { return nil }
+		idx.indexFile(rel, string(body))
+		idx.mtimes[rel] = info.ModTime()
+		return nil
+	})
+	if err != nil { return err }
+	for rel := range idx.mtimes {
+		if !seen[rel] {
+			idx.removeFile(rel)
+			delete(idx.mtimes, rel)
+		}
+	}
+	return nil
+}
+
+func (idx *searchIndex) indexFile(path, body string) {
+	idx.removeFile(path)
+	idx.content[path] = body
+	for pos, tok := range tokenize(body) {
+		if idx.postings[tok] == nil { idx.postings[tok] = map[string][]int{} }
+		idx.postings[tok][path] = append(idx.postings[tok][path], pos)
+	}
+}
+
+func (idx *searchIndex) removeFile(path string) {
+	delete(idx.content, path)
+	for tok, paths := range idx.postings {
+		delete(paths, path)
+		if len(paths) == 0 { delete(idx.postings, tok) }
+	}
+}
+
+// tokenize lowercases s and splits it into runs of letters and digits.
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// snippet returns a ~160 character window of body around the first matched
+// term, with every matched term wrapped in <mark>. If nothing matches, it
+// returns a plain leading excerpt.
+func snippet(body string, terms []string) template.HTML {
+	lower := strings.ToLower(body)
+	first := -1
+	for _, t := range terms {
+		if t == "" { continue }
+		if i := strings.Index(lower, t); i != -1 && (first == -1 || i < first) { first = i }
+	}
+	if first == -1 {
+		end := runeBoundary(body, snippetRadius*2, false)
+		return template.HTML(template.HTMLEscapeString(body[:end]))
+	}
+	start := runeBoundary(body, first-snippetRadius, true)
+	end := runeBoundary(body, first+snippetRadius, false)
+	return template.HTML(highlight(body[start:end], terms))
+}
+
+// runeBoundary clamps n into [0, len(s)] and then nudges it to the nearest
+// UTF-8 rune boundary - backward if back is true, forward otherwise - so
+// callers never slice s in the middle of a multi-byte rune.
+func runeBoundary(s string, n int, back bool) int {
+	if n < 0 { n = 0 }
+	if n > len(s) { n = len(s) }
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		if back { n-- } else { n++ }
+	}
+	return n
+}
+
+// highlight HTML-escapes window and wraps every non-overlapping occurrence
+// of each term in <mark>.
+func highlight(window string, terms []string) string {
+	escaped := template.HTMLEscapeString(window)
+	lower := strings.ToLower(escaped)
+	type span struct{ start, end int }
+	var spans []span
+	for _, t := range terms {
+		if t == "" { continue }
+		for i := 0; i < len(lower); {
+			j := strings.Index(lower[i:], t)
+			if j == -1 { break }
+			spans = append(spans, span{i + j, i + j + len(t)})
+			i += j + len(t)
+		}
+	}
+	if len(spans) == 0 { return escaped }
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		if s.start < pos { continue }
+		b.WriteString(escaped[pos:s.start])
+		b.WriteString("<mark>")
+		b.WriteString(escaped[s.start:s.end])
+		b.WriteString("</mark>")
+		pos = s.end
+	}
+	b.WriteString(escaped[pos:])
+	return b.String()
+}
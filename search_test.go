@@ -0,0 +1,101 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func newTestWiki(t *testing.T) *Wiki {
+	dir, err := os.MkdirTemp("", "wik-search-test")
+	if err != nil { t.Fatal(err) }
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return &Wiki{Path: dir}
+}
+
+func writeTestPage(t *testing.T, w *Wiki, rel, body string) {
+	full := filepath.Join(w.Path, rel)
+	if err := os.MkdirAll(filepath.Dir(full), 0777); err != nil { t.Fatal(err) }
+	if err := os.WriteFile(full, []byte(body), 0600); err != nil { t.Fatal(err) }
+}
+
+func TestSearchRanksTitleMatchHigher(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "Gardening.md", "# Gardening\n\nTips for tomatoes and soil.")
+	writeTestPage(t, w, "Cooking.md", "# Cooking\n\nA recipe that mentions gardening once in passing.")
+
+	hits, err := w.Search("gardening", 10)
+	if err != nil { t.Fatal(err) }
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %d", len(hits))
+	}
+	if hits[0].Title != "Gardening" {
+		t.Errorf("expected title match to rank first, got %q first", hits[0].Title)
+	}
+}
+
+func TestSearchSnippetHighlightsMatch(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "Notes.md", "Some long preamble text. The word tomatoes appears here.")
+
+	hits, err := w.Search("tomatoes", 10)
+	if err != nil { t.Fatal(err) }
+	if len(hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(hits))
+	}
+	if !strings.Contains(string(hits[0].Snippet), "<mark>tomatoes</mark>") {
+		t.Errorf("expected snippet to highlight match, got %q", hits[0].Snippet)
+	}
+}
+
+func TestSearchSnippetHandlesMultibyteBoundary(t *testing.T) {
+	w := newTestWiki(t)
+	// "café" repeated pushes the snippet window's start/end cut points to
+	// land inside one of its multi-byte "é" runes for some repeat counts;
+	// this must not panic or produce invalid UTF-8.
+	body := strings.Repeat("café ", 40) + "tomatoes"
+	writeTestPage(t, w, "Notes.md", body)
+
+	hits, err := w.Search("tomatoes", 10)
+	if err != nil { t.Fatal(err) }
+	if len(hits) != 1 {
+		t.Fatalf("expected 1 hit, got %d", len(hits))
+	}
+	if !utf8.ValidString(string(hits[0].Snippet)) {
+		t.Errorf("snippet is not valid UTF-8: %q", hits[0].Snippet)
+	}
+	if !strings.Contains(string(hits[0].Snippet), "<mark>tomatoes</mark>") {
+		t.Errorf("expected snippet to highlight match, got %q", hits[0].Snippet)
+	}
+}
+
+func TestSearchReindexesChangedFile(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "Notes.md", "original content")
+
+	if hits, err := w.Search("updated", 10); err != nil || len(hits) != 0 {
+		t.Fatalf("expected no hits before edit, got %d hits, err %v", len(hits), err)
+	}
+
+	writeTestPage(t, w, "Notes.md", "updated content")
+	hits, err := w.Search("updated", 10)
+	if err != nil { t.Fatal(err) }
+	if len(hits) != 1 {
+		t.Fatalf("expected 1 hit after edit, got %d", len(hits))
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("Café Rules! 2024")
+	want := []string{"café", "rules", "2024"}
+	if len(got) != len(want) {
+		t.Fatalf("tokenize returned %v, want %v", got, want)
+	}
+	for ix := range want {
+		if got[ix] != want[ix] {
+			t.Errorf("tokenize()[%d] = %q, want %q", ix, got[ix], want[ix])
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 	"net/http"
+	"encoding/json"
 	"html/template"
 	"path/filepath"
 	"strings"
@@ -11,9 +12,22 @@ import (
 func WikiHandlers (wiki *Wiki) {
 	http.HandleFunc("/", ShowPage(wiki))
 	http.HandleFunc("/edit/", ShowEdit(wiki))
+	http.HandleFunc("/history/", ShowHistory(wiki))
+	http.HandleFunc("/diff/", ShowDiff(wiki))
 	http.HandleFunc("/api/remove/", RemovePage(wiki))
 	http.HandleFunc("/api/edit/", EditPage(wiki))
 	http.HandleFunc("/api/create/", CreatePage(wiki))
+	http.HandleFunc("/api/revert/", RevertPage(wiki))
+	http.HandleFunc("/_pages", ShowAllPages(wiki))
+	http.HandleFunc("/feed.atom", ShowFeed(wiki))
+	http.HandleFunc("/search", ShowSearch(wiki))
+}
+
+// PathFromRequest strips the given route prefix from the request's URL path,
+// leaving the web path every handler should hand to the wiki and wikipath
+// helpers instead of slicing r.URL.Path itself.
+func PathFromRequest(r *http.Request, prefix string) string {
+	return strings.TrimPrefix(r.URL.Path, prefix)
 }
 
 func ShowPage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
@@ -21,18 +35,26 @@ func ShowPage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
 	create, _ := template.ParseFiles("static/templates/create.html")
 	flist, _ := template.ParseFiles("static/templates/list.html")
 	return func (w http.ResponseWriter, r *http.Request) {
-		p, err := wiki.Local(r.URL.Path)
-		if err == nil { 
-			info, err := os.Stat(p)
-			if err == nil && info.IsDir() {
-				dir, e := wiki.GetDir(r.URL.Path)
-				if e == nil { flist.Execute(w, dir) }
-			} else if err == nil {
-				pg, e := wiki.GetPage(r.URL.Path)
+		path := r.URL.Path
+		if IsReserved(path) {
+			http.NotFound(w, r)
+			return
+		}
+		dirPath, err := wiki.Local(path)
+		if err != nil { return }
+		info, statErr := os.Stat(dirPath)
+		if statErr == nil && info.IsDir() {
+			dir, e := wiki.GetDir(path)
+			if e == nil { flist.Execute(w, dir) }
+		} else {
+			pg, e := wiki.GetPage(path)
+			if e == nil {
 				pg.ProcessMarkdown()
-				if e == nil { show.Execute(w, pg) }
+				pg.Sidebar, _ = wiki.GetSidebar(filepath.Dir(path))
+				pg.Footer, _ = wiki.GetFooter(filepath.Dir(path))
+				show.Execute(w, pg)
 			} else {
-				create.Execute(w, r.URL.Path)
+				create.Execute(w, path)
 			}
 		}
 	}
@@ -41,40 +63,122 @@ func ShowPage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
 func ShowEdit (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
 	t, _ := template.ParseFiles("static/templates/edit.html")
 	return func (w http.ResponseWriter, r *http.Request) {
-		pg, err := wiki.GetPage(r.URL.Path[len("/edit/"):])
+		pg, err := wiki.GetPage(PathFromRequest(r, "/edit/"))
 		if err == nil { t.Execute(w, pg) }
 	}
 }
 
+type HistoryView struct {
+	Path string
+	Revisions []Revision
+}
+
+func ShowHistory (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
+	t, _ := template.ParseFiles("static/templates/history.html")
+	return func (w http.ResponseWriter, r *http.Request) {
+		path := PathFromRequest(r, "/history/")
+		revs, err := wiki.History(path, 50)
+		if err == nil { t.Execute(w, HistoryView{Path: path, Revisions: revs}) }
+	}
+}
+
+type DiffView struct {
+	Path string
+	OldSha string
+	NewSha string
+	Diff string
+}
+
+func ShowDiff (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
+	t, _ := template.ParseFiles("static/templates/diff.html")
+	return func (w http.ResponseWriter, r *http.Request) {
+		path := PathFromRequest(r, "/diff/")
+		old := r.URL.Query().Get("old")
+		new_ := r.URL.Query().Get("new")
+		d, err := wiki.Diff(path, old, new_)
+		if err == nil { t.Execute(w, DiffView{Path: path, OldSha: old, NewSha: new_, Diff: d}) }
+	}
+}
+
+func RevertPage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
+	return func (w http.ResponseWriter, r *http.Request) {
+		path := PathFromRequest(r, "/api/revert/")
+		sha := r.URL.Query().Get("sha")
+		err := wiki.Revert(path, sha)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, "/" + path, http.StatusFound)
+	}
+}
+
+type SearchView struct {
+	Query string
+	Hits []SearchHit
+}
+
+func ShowSearch (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
+	t, _ := template.ParseFiles("static/templates/search.html")
+	return func (w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query().Get("q")
+		hits, err := wiki.Search(q, 50)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(hits)
+			return
+		}
+		t.Execute(w, SearchView{Query: q, Hits: hits})
+	}
+}
+
+func ShowAllPages (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
+	t, _ := template.ParseFiles("static/templates/pages.html")
+	return func (w http.ResponseWriter, r *http.Request) {
+		pages, err := wiki.AllPages()
+		if err == nil { t.Execute(w, pages) }
+	}
+}
+
 func RemovePage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
 	return func (w http.ResponseWriter, r *http.Request) {
-		err := wiki.Remove(r.URL.Path[len("/api/remove/"):])
-		if err == nil {
-			path := r.URL.Path[len("/api/remove"):]
-			http.Redirect(w, r, filepath.Dir(path), http.StatusFound)
+		path := PathFromRequest(r, "/api/remove/")
+		err := wiki.Remove(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		http.Redirect(w, r, filepath.Dir("/" + path), http.StatusFound)
 	}
 }
 
 func CreatePage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
 	return func (w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path[len("/api/create/"):]
+		path := PathFromRequest(r, "/api/create/")
 		err := wiki.Create(path)
-		if err == nil {
-			http.Redirect(w, r, "/" + path, http.StatusFound)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		http.Redirect(w, r, "/" + path, http.StatusFound)
 	}
 }
 
 func EditPage (wiki *Wiki) func (http.ResponseWriter, *http.Request) {
 	return func (w http.ResponseWriter, r *http.Request) {
-		path := r.URL.Path[len("/api/edit/"):]
+		path := PathFromRequest(r, "/api/edit/")
 		r.ParseForm()
 		body := r.Form.Get("new_contents")
 		err := wiki.Edit(path, []byte(body))
-		if err == nil {
-			http.Redirect(w, r, "/" + path, http.StatusFound)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
+		http.Redirect(w, r, "/" + path, http.StatusFound)
 	}
 }
 
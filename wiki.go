@@ -5,7 +5,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"io/ioutil"
 	"github.com/microcosm-cc/bluemonday"
 	"github.com/russross/blackfriday"
@@ -21,6 +26,15 @@ type Page struct {
 	URI string
 	Raw string
 	Body template.HTML
+	Sidebar template.HTML
+	Footer template.HTML
+}
+
+// PageEntry describes an entry in the flat page index returned by AllPages.
+type PageEntry struct {
+	URI string
+	Title string
+	Modified time.Time
 }
 
 type PageInfo struct {
@@ -29,22 +43,65 @@ type PageInfo struct {
 	IsDir bool
 }
 
+// Revision describes a single commit that touched a page.
+type Revision struct {
+	SHA string
+	Author string
+	Date string
+	Message string
+}
+
+// Change describes a single commit and the pages it touched, as reported
+// by RecentChanges.
+type Change struct {
+	SHA string
+	Author string
+	Timestamp time.Time
+	Message string
+	Paths []string
+}
+
 
 ////////// Mutating operations
 
+// wikiLocks holds one mutex per wiki path, so concurrent editors of the same
+// wiki serialize on write+commit while editors of different wikis don't
+// contend with each other.
+var wikiLocksMu sync.Mutex
+var wikiLocks = map[string]*sync.Mutex{}
+
+// lock acquires the exclusive lock for this wiki, returning a closure that
+// releases it. Every mutating method wraps its write-then-commit sequence in
+// this, so two concurrent edits can't interleave their git invocations.
+func (w *Wiki) lock() func() {
+	wikiLocksMu.Lock()
+	m, ok := wikiLocks[w.Path]
+	if !ok {
+		m = &sync.Mutex{}
+		wikiLocks[w.Path] = m
+	}
+	wikiLocksMu.Unlock()
+	m.Lock()
+	return m.Unlock
+}
+
 // Create creates a new file in the given wiki
 func (w *Wiki) Create(path string) error {
-	p, err := w.Local(path)
+	if IsReserved(path) { return errors.New("reserved page name") }
+	defer w.lock()()
+	p, err := w.Local(WebPathToGitPath(path))
 	if (err != nil) { return err }
 	err = os.MkdirAll(filepath.Dir(p), 0777)
-	err = ioutil.WriteFile(p, []byte("# " + path), 0600)
+	err = ioutil.WriteFile(p, []byte("# " + WebPathToUserTitle(path)), 0600)
 	if (err != nil) { return err }
 	return w.Commit(p, "Created " + path)
 }
 
 // Edit changes the contents of a file in the given wiki
 func (w *Wiki) Edit(path string, contents []byte) error {
-	p, err := w.Local(path)
+	if IsReserved(path) { return errors.New("reserved page name") }
+	defer w.lock()()
+	p, err := w.findFile(path)
 	if (err != nil) { return err }
 	err = ioutil.WriteFile(p, contents, 0600)
 	if (err != nil) { return err }
@@ -54,7 +111,9 @@ func (w *Wiki) Edit(path string, contents []byte) error {
 // Remove removes a file in the given wiki
 // TODO - remove the containing directory if empty
 func (w *Wiki) Remove(path string) error {
-	p, err := w.Local(path)
+	if IsReserved(path) { return errors.New("reserved page name") }
+	defer w.lock()()
+	p, err := w.findFile(path)
 	if (err != nil) { return err }
 	err = os.Remove(p)
 	if (err != nil) { return err }
@@ -74,7 +133,12 @@ func (w *Wiki) GetDir(path string) ([]PageInfo, error) {
 		f := files[ix]
 		n := f.Name()
 		if !strings.HasPrefix(n, ".") {
-			inf := PageInfo{Name: n, URI: filepath.Join(path, n), IsDir: f.IsDir()}
+			name, uri := n, filepath.Join(path, n)
+			if !f.IsDir() && strings.HasSuffix(n, canonicalExt) {
+				name = WebPathToUserTitle(strings.TrimSuffix(n, canonicalExt))
+				uri = filepath.Join(path, UserTitleToWebPath(name))
+			}
+			inf := PageInfo{Name: name, URI: uri, IsDir: f.IsDir()}
 			res = append(res, inf)
 		}
 	}
@@ -85,7 +149,7 @@ func (w *Wiki) GetDir(path string) ([]PageInfo, error) {
 // Does not render input by default; if rendered output is desired, the caller
 // should also call .Render on the result of GetPage
 func (w *Wiki) GetPage(path string) (*Page, error) {
-	p, err := w.Local(path)
+	p, err := w.findFile(path)
 	if err != nil { return &Page{}, err }
 	body, err := ioutil.ReadFile(p)
 	if err != nil { return &Page{}, err }
@@ -97,6 +161,271 @@ func (pg *Page) ProcessMarkdown() {
 	pg.Body = template.HTML(bluemonday.UGCPolicy().SanitizeBytes(unsafe))
 }
 
+////////// Sidebar, footer and page index
+
+const sidebarName = "_Sidebar.md"
+const footerName = "_Footer.md"
+
+// GetSidebar walks up from dir to the wiki root looking for a _Sidebar.md,
+// rendering the first one it finds. Returns empty output, no error if none
+// of the directories on the way up have one.
+func (w *Wiki) GetSidebar(dir string) (template.HTML, error) {
+	return w.getConventionalPage(dir, sidebarName)
+}
+
+// GetFooter walks up from dir to the wiki root looking for a _Footer.md,
+// rendering the first one it finds. Returns empty output, no error if none
+// of the directories on the way up have one.
+func (w *Wiki) GetFooter(dir string) (template.HTML, error) {
+	return w.getConventionalPage(dir, footerName)
+}
+
+func (w *Wiki) getConventionalPage(dir, name string) (template.HTML, error) {
+	p, err := w.Local(dir)
+	if err != nil { return "", err }
+	for {
+		body, err := ioutil.ReadFile(filepath.Join(p, name))
+		if err == nil {
+			pg := &Page{Raw: string(body)}
+			pg.ProcessMarkdown()
+			return pg.Body, nil
+		}
+		if p == w.Path { return "", nil }
+		parent := filepath.Dir(p)
+		if parent == p { return "", nil }
+		p = parent
+	}
+}
+
+// AllPages walks the whole wiki tree, skipping .git and hidden files, and
+// returns a flat, sorted list of every page with its last-modified time.
+func (w *Wiki) AllPages() ([]PageEntry, error) {
+	res := make([]PageEntry, 0)
+	err := filepath.Walk(w.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil { return err }
+		if p == w.Path { return nil }
+		base := filepath.Base(p)
+		if strings.HasPrefix(base, ".") {
+			if info.IsDir() { return filepath.SkipDir }
+			return nil
+		}
+		if info.IsDir() || !strings.HasSuffix(base, canonicalExt) { return nil }
+		rel, err := filepath.Rel(w.Path, p)
+		if err != nil { return err }
+		title := WebPathToUserTitle(strings.TrimSuffix(rel, canonicalExt))
+		res = append(res, PageEntry{URI: UserTitleToWebPath(title), Title: title, Modified: w.lastModified(rel)})
+		return nil
+	})
+	if err != nil { return nil, err }
+	sort.Slice(res, func(i, j int) bool { return res[i].URI < res[j].URI })
+	return res, nil
+}
+
+// commitTimeEntry is one cached result in a commitTimeCache: the commit time
+// found by the last "git log" for a path, and the file mtime it was found
+// at, so a later on-disk change invalidates it.
+type commitTimeEntry struct {
+	mtime time.Time
+	commitTime time.Time
+}
+
+// commitTimeCache caches lastModified's git-log lookups per wiki so AllPages
+// doesn't shell out to git once per page on every request; mirrors the
+// per-path search index pool in search.go.
+type commitTimeCache struct {
+	mu sync.Mutex
+	entries map[string]commitTimeEntry
+}
+
+func newCommitTimeCache() *commitTimeCache {
+	return &commitTimeCache{entries: map[string]commitTimeEntry{}}
+}
+
+var commitTimeCachesMu sync.Mutex
+var commitTimeCaches = map[string]*commitTimeCache{}
+
+func (w *Wiki) commitTimes() *commitTimeCache {
+	commitTimeCachesMu.Lock()
+	defer commitTimeCachesMu.Unlock()
+	c, ok := commitTimeCaches[w.Path]
+	if !ok {
+		c = newCommitTimeCache()
+		commitTimeCaches[w.Path] = c
+	}
+	return c
+}
+
+// lastModified returns the commit time of the most recent commit touching
+// rel, or the zero time if that can't be determined. Results are cached per
+// wiki, keyed by rel and invalidated when the file's mtime changes, so
+// repeated calls (as AllPages makes, one per page) only shell out to git for
+// files that actually changed since the last call.
+func (w *Wiki) lastModified(rel string) time.Time {
+	info, err := os.Stat(filepath.Join(w.Path, rel))
+	if err != nil { return time.Time{} }
+	mtime := info.ModTime()
+
+	cache := w.commitTimes()
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if entry, ok := cache.entries[rel]; ok && entry.mtime.Equal(mtime) {
+		return entry.commitTime
+	}
+
+	out, err := w.execOut("git", "log", "-1", "--format=%ct", "--", rel)
+	if err != nil { return time.Time{} }
+	sec, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil { return time.Time{} }
+	commitTime := time.Unix(sec, 0)
+	cache.entries[rel] = commitTimeEntry{mtime: mtime, commitTime: commitTime}
+	return commitTime
+}
+
+////////// History, diff and revert
+
+// history field/record separators; chosen to avoid collision with commit messages
+const historyFieldSep = "\x1f"
+const historyRecordSep = "\x1e"
+
+// History returns up to limit Revisions touching path, newest first.
+// A limit of 0 or less returns the full history.
+func (w *Wiki) History(path string, limit int) ([]Revision, error) {
+	rel, err := w.relPath(path)
+	if err != nil { return nil, err }
+	args := []string{"log", "--pretty=format:%H" + historyFieldSep + "%an" + historyFieldSep + "%ad" + historyFieldSep + "%s" + historyRecordSep, "--date=iso-strict"}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	args = append(args, "--", rel)
+	out, err := w.execOut("git", args...)
+	if err != nil { return nil, err }
+	records := strings.Split(strings.Trim(out, historyRecordSep+"\n"), historyRecordSep)
+	res := make([]Revision, 0, len(records))
+	for _, rec := range records {
+		rec = strings.Trim(rec, "\n")
+		if rec == "" { continue }
+		fields := strings.Split(rec, historyFieldSep)
+		if len(fields) != 4 { continue }
+		res = append(res, Revision{SHA: fields[0], Author: fields[1], Date: fields[2], Message: fields[3]})
+	}
+	return res, nil
+}
+
+// validRef matches the restricted set of characters we accept in a git
+// revision expression - a commit SHA, optionally followed by ~N/^N parent
+// selectors (such as the "SHA~1" in the history template's diff links). It
+// must start with an alphanumeric, so it can never be mistaken by git for a
+// flag.
+var validRef = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._^~]*$`)
+
+// isValidRef reports whether ref is safe to pass to git as a revision
+// argument. PageAt/Diff/Revert all take revisions, which for Diff and Revert
+// come straight from HTTP requests, and git treats any argument starting
+// with "-" as a flag rather than a revision, so this must run before any of
+// them reach exec.Command.
+func isValidRef(ref string) bool {
+	return validRef.MatchString(ref)
+}
+
+// PageAt returns the contents of path as of the given commit sha.
+func (w *Wiki) PageAt(path, sha string) (*Page, error) {
+	if !isValidRef(sha) { return &Page{}, errors.New("invalid revision") }
+	rel, err := w.relPath(path)
+	if err != nil { return &Page{}, err }
+	out, err := w.execOut("git", "show", sha+":"+rel)
+	if err != nil { return &Page{}, err }
+	p, err := w.findFile(path)
+	if err != nil { return &Page{}, err }
+	return &Page{Path: p, URI: filepath.Clean(path), Raw: out}, nil
+}
+
+// Diff returns a unified diff of path between oldSha and newSha.
+func (w *Wiki) Diff(path, oldSha, newSha string) (string, error) {
+	if !isValidRef(oldSha) || !isValidRef(newSha) { return "", errors.New("invalid revision") }
+	rel, err := w.relPath(path)
+	if err != nil { return "", err }
+	return w.execOut("git", "diff", oldSha, newSha, "--", rel)
+}
+
+// Revert writes the contents of path as of sha back to disk and commits the result.
+func (w *Wiki) Revert(path, sha string) error {
+	if !isValidRef(sha) { return errors.New("invalid revision") }
+	pg, err := w.PageAt(path, sha)
+	if err != nil { return err }
+	defer w.lock()()
+	p, err := w.findFile(path)
+	if err != nil { return err }
+	err = ioutil.WriteFile(p, []byte(pg.Raw), 0600)
+	if err != nil { return err }
+	return w.Commit(p, "Reverted " + path + " to " + sha)
+}
+
+// changeHeaderSep marks the start of a commit header line in the
+// --name-status log RecentChanges parses; chosen to avoid collision with
+// both commit messages and the "X\tpath" status lines that follow them.
+const changeHeaderSep = "\x01"
+
+// RecentChanges returns up to limit Changes across the whole wiki, newest
+// first, each carrying the paths the commit touched.
+func (w *Wiki) RecentChanges(limit int) ([]Change, error) {
+	format := changeHeaderSep + "%H" + historyFieldSep + "%an" + historyFieldSep + "%at" + historyFieldSep + "%s"
+	args := []string{"log", "--name-status", "--pretty=format:" + format}
+	if limit > 0 {
+		args = append(args, "-n", strconv.Itoa(limit))
+	}
+	out, err := w.execOut("git", args...)
+	if err != nil { return nil, err }
+	res := make([]Change, 0)
+	var cur *Change
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, changeHeaderSep) {
+			if cur != nil { res = append(res, *cur) }
+			cur = nil
+			fields := strings.Split(strings.TrimPrefix(line, changeHeaderSep), historyFieldSep)
+			if len(fields) != 4 { continue }
+			sec, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil { continue }
+			cur = &Change{SHA: fields[0], Author: fields[1], Timestamp: time.Unix(sec, 0), Message: fields[3]}
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || cur == nil { continue }
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) == 2 { cur.Paths = append(cur.Paths, parts[1]) }
+	}
+	if cur != nil { res = append(res, *cur) }
+	return res, nil
+}
+
+// relPath resolves path to an on-disk file and returns it relative to the
+// repo root, suitable for passing to git as a pathspec.
+func (w *Wiki) relPath(path string) (string, error) {
+	p, err := w.findFile(path)
+	if err != nil { return "", err }
+	return filepath.Rel(w.Path, p)
+}
+
+// findFile resolves a web path to an existing on-disk file, treating
+// dashes and spaces in the final segment as equivalent so legacy file names
+// (stored before the dash convention, or created by hand) still resolve. If
+// no existing file matches, it returns the canonical path so callers doing
+// a write still get a sensible destination.
+func (w *Wiki) findFile(path string) (string, error) {
+	p, err := w.Local(WebPathToGitPath(path))
+	if err != nil { return "", err }
+	if _, err := os.Stat(p); err == nil { return p, nil }
+	dir := filepath.Dir(p)
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil { return p, nil }
+	want := filepath.Base(p)
+	for _, e := range entries {
+		if SameFilename(e.Name(), want) {
+			return filepath.Join(dir, e.Name()), nil
+		}
+	}
+	return p, nil
+}
+
 ////////// Git commands and various utility
 
 // Initialize runs git-init in the directory of the given wiki
@@ -104,11 +433,17 @@ func (w *Wiki) Initialize() error {
 	return w.ExecIn("git", "init")
 }
 
-// Commit runs a git-add/git-commit with the given message and file
+// Commit runs a git-add/git-commit with the given message and file. Staging
+// a no-op edit (contents identical to what's already committed) leaves
+// nothing in the index, which would otherwise make "git commit" exit 1 with
+// "nothing to commit" - that's not a real failure, so Commit returns nil
+// without running "git commit".
 func (w *Wiki) Commit(path string, message string) error {
-	w.ExecIn("git", "add", "--all", path)
-	w.ExecIn("git", "commit", "-m", message)
-	return nil
+	if err := w.ExecIn("git", "add", "--all", path); err != nil { return err }
+	if err := w.ExecIn("git", "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+	return w.ExecIn("git", "commit", "-m", message)
 }
 
 // ExecIn executes a command with the wiki directory as CWD.
@@ -118,6 +453,14 @@ func (w *Wiki) ExecIn(command string, args ...string) error {
 	return cmd.Run()
 }
 
+// execOut executes a command with the wiki directory as CWD and returns its stdout.
+func (w *Wiki) execOut(command string, args ...string) (string, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Dir = w.Path
+	out, err := cmd.Output()
+	return string(out), err
+}
+
 // Local takes a path and checks if it would fall within the given
 // repo if joined with it. Returns either 
 //   [sanitized path], nil    // if the given path is valid
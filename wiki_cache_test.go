@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLastModifiedCachesByMtime(t *testing.T) {
+	w := newGitTestWiki(t)
+	if err := w.Create("Notes"); err != nil { t.Fatal(err) }
+
+	real := w.lastModified("Notes.md")
+	if real.IsZero() {
+		t.Fatal("expected a non-zero commit time after Create")
+	}
+
+	// Poison the cache entry for this rel with a bogus commit time, keeping
+	// the cached mtime in sync with what's on disk. If lastModified
+	// consults the cache instead of re-running "git log", it returns the
+	// bogus value rather than recomputing the real one.
+	info, err := os.Stat(filepath.Join(w.Path, "Notes.md"))
+	if err != nil { t.Fatal(err) }
+	bogus := real.Add(24 * time.Hour)
+	cache := w.commitTimes()
+	cache.mu.Lock()
+	cache.entries["Notes.md"] = commitTimeEntry{mtime: info.ModTime(), commitTime: bogus}
+	cache.mu.Unlock()
+
+	if got := w.lastModified("Notes.md"); !got.Equal(bogus) {
+		t.Errorf("expected lastModified to return the cached value %v, got %v", bogus, got)
+	}
+
+	// Editing the file advances its mtime, which must invalidate the cache
+	// entry and force a fresh "git log" lookup.
+	time.Sleep(10 * time.Millisecond)
+	if err := w.Edit("Notes", []byte("# Notes\n\nupdated")); err != nil { t.Fatal(err) }
+
+	if got := w.lastModified("Notes.md"); got.Equal(bogus) {
+		t.Error("expected an mtime change to invalidate the cached commit time")
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestRecentChanges(t *testing.T) {
+	w := newGitTestWiki(t)
+
+	if err := w.Create("Notes"); err != nil { t.Fatal(err) }
+	if err := w.Edit("Notes", []byte("# Notes\n\nupdated")); err != nil { t.Fatal(err) }
+	if err := w.Create("Other"); err != nil { t.Fatal(err) }
+
+	changes, err := w.RecentChanges(0)
+	if err != nil { t.Fatal(err) }
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d: %+v", len(changes), changes)
+	}
+
+	newest := changes[0]
+	if newest.Message != "Created Other" {
+		t.Errorf("expected newest change to be the most recent commit, got %q", newest.Message)
+	}
+	if len(newest.Paths) != 1 {
+		t.Fatalf("expected the Other commit to touch exactly one path, got %v", newest.Paths)
+	}
+}
+
+func TestRecentChangesRespectsLimit(t *testing.T) {
+	w := newGitTestWiki(t)
+
+	if err := w.Create("Notes"); err != nil { t.Fatal(err) }
+	if err := w.Create("Other"); err != nil { t.Fatal(err) }
+
+	changes, err := w.RecentChanges(1)
+	if err != nil { t.Fatal(err) }
+	if len(changes) != 1 {
+		t.Fatalf("expected 1 change with limit 1, got %d", len(changes))
+	}
+	if changes[0].Message != "Created Other" {
+		t.Errorf("expected the limited result to be the newest change, got %q", changes[0].Message)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCommitNoOpIsNotAnError(t *testing.T) {
+	w := newGitTestWiki(t)
+	if err := w.Create("Notes"); err != nil { t.Fatal(err) }
+
+	// Writing the exact same content Create already committed leaves nothing
+	// staged; this must succeed rather than surface git's "nothing to
+	// commit" exit code as an error.
+	pg, err := w.GetPage("Notes")
+	if err != nil { t.Fatal(err) }
+	if err := w.Edit("Notes", []byte(pg.Raw)); err != nil {
+		t.Fatalf("expected a no-op edit to succeed, got %v", err)
+	}
+}
+
+func TestCommitPropagatesRealErrors(t *testing.T) {
+	w := newTestWiki(t) // no Initialize() - not a git repo
+
+	if err := w.Commit(w.Path, "should fail"); err == nil {
+		t.Fatal("expected Commit to fail when the wiki isn't a git repo")
+	}
+}
+
+func TestLockSerializesConcurrentWrites(t *testing.T) {
+	w := &Wiki{Path: "/fake/path/for/locking/test"}
+
+	var mu sync.Mutex
+	inCriticalSection := false
+	overlapped := false
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.lock()()
+			mu.Lock()
+			if inCriticalSection { overlapped = true }
+			inCriticalSection = true
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			inCriticalSection = false
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if overlapped {
+		t.Error("expected lock() to serialize concurrent critical sections for the same wiki path")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// newGitTestWiki returns a Wiki backed by a real, initialized git repo, with
+// committer identity configured so ExecIn("git", "commit", ...) succeeds.
+func newGitTestWiki(t *testing.T) *Wiki {
+	w := newTestWiki(t)
+	if err := w.Initialize(); err != nil { t.Fatal(err) }
+	if err := w.ExecIn("git", "config", "user.email", "test@example.com"); err != nil { t.Fatal(err) }
+	if err := w.ExecIn("git", "config", "user.name", "Test"); err != nil { t.Fatal(err) }
+	return w
+}
+
+func TestIsValidRef(t *testing.T) {
+	cases := []struct {
+		ref string
+		want bool
+	}{
+		{"abc123", true},
+		{"HEAD~1", true},
+		{"HEAD^2", true},
+		{"a.b_c", true},
+		{"", false},
+		{"-force", false},
+		{"--upload-pack=evil", false},
+		{"/etc/passwd", false},
+	}
+	for _, c := range cases {
+		if got := isValidRef(c.ref); got != c.want {
+			t.Errorf("isValidRef(%q) = %v, want %v", c.ref, got, c.want)
+		}
+	}
+}
+
+func TestHistoryPageAtDiffRevert(t *testing.T) {
+	w := newGitTestWiki(t)
+
+	if err := w.Create("Notes"); err != nil { t.Fatal(err) }
+	if err := w.Edit("Notes", []byte("# Notes\n\nfirst revision")); err != nil { t.Fatal(err) }
+	if err := w.Edit("Notes", []byte("# Notes\n\nsecond revision")); err != nil { t.Fatal(err) }
+
+	revs, err := w.History("Notes", 0)
+	if err != nil { t.Fatal(err) }
+	if len(revs) != 3 {
+		t.Fatalf("expected 3 revisions, got %d", len(revs))
+	}
+
+	newest, oldest := revs[0], revs[len(revs)-1]
+
+	pg, err := w.PageAt("Notes", oldest.SHA)
+	if err != nil { t.Fatal(err) }
+	if !strings.Contains(pg.Raw, "# Notes") {
+		t.Errorf("PageAt(oldest) = %q, want the original content", pg.Raw)
+	}
+
+	diff, err := w.Diff("Notes", oldest.SHA, newest.SHA)
+	if err != nil { t.Fatal(err) }
+	if !strings.Contains(diff, "second revision") {
+		t.Errorf("Diff did not mention the newer content: %q", diff)
+	}
+
+	if err := w.Revert("Notes", oldest.SHA); err != nil { t.Fatal(err) }
+	pg, err = w.GetPage("Notes")
+	if err != nil { t.Fatal(err) }
+	if strings.Contains(pg.Raw, "second revision") {
+		t.Errorf("Revert did not restore the original content, got %q", pg.Raw)
+	}
+
+	if _, err := w.PageAt("Notes", "--upload-pack=evil"); err == nil {
+		t.Error("expected PageAt to reject an unsafe revision")
+	}
+	if _, err := w.Diff("Notes", "-x", newest.SHA); err == nil {
+		t.Error("expected Diff to reject an unsafe revision")
+	}
+	if err := w.Revert("Notes", "-x"); err == nil {
+		t.Error("expected Revert to reject an unsafe revision")
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGetSidebarAndFooterWalkUpToRoot(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "_Sidebar.md", "root sidebar")
+	writeTestPage(t, w, "_Footer.md", "root footer")
+	writeTestPage(t, w, "Projects/Notes.md", "# Notes")
+
+	sidebar, err := w.GetSidebar("Projects")
+	if err != nil { t.Fatal(err) }
+	if sidebar == "" {
+		t.Error("expected GetSidebar to find the root _Sidebar.md from a subdirectory")
+	}
+
+	footer, err := w.GetFooter("Projects")
+	if err != nil { t.Fatal(err) }
+	if footer == "" {
+		t.Error("expected GetFooter to find the root _Footer.md from a subdirectory")
+	}
+}
+
+func TestGetSidebarPrefersNearestAncestor(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "_Sidebar.md", "root sidebar")
+	writeTestPage(t, w, "Projects/_Sidebar.md", "projects sidebar")
+
+	sidebar, err := w.GetSidebar("Projects")
+	if err != nil { t.Fatal(err) }
+	if !strings.Contains(string(sidebar), "projects sidebar") || strings.Contains(string(sidebar), "root sidebar") {
+		t.Errorf("GetSidebar(%q) = %q, want the nearest ancestor's content", "Projects", sidebar)
+	}
+}
+
+func TestGetSidebarReturnsEmptyWhenAbsent(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "Notes.md", "# Notes")
+
+	sidebar, err := w.GetSidebar("")
+	if err != nil { t.Fatal(err) }
+	if sidebar != "" {
+		t.Errorf("expected no sidebar, got %q", sidebar)
+	}
+}
+
+func TestAllPagesListsSortedByURI(t *testing.T) {
+	w := newTestWiki(t)
+	writeTestPage(t, w, "Banana.md", "# Banana")
+	writeTestPage(t, w, "Apple.md", "# Apple")
+	writeTestPage(t, w, ".hidden.md", "not a page")
+
+	entries, err := w.AllPages()
+	if err != nil { t.Fatal(err) }
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 pages (hidden file excluded), got %d: %+v", len(entries), entries)
+	}
+	if entries[0].Title != "Apple" || entries[1].Title != "Banana" {
+		t.Errorf("expected pages sorted by URI, got %q then %q", entries[0].Title, entries[1].Title)
+	}
+}
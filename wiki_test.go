@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditRejectsReservedNames(t *testing.T) {
+	w := newTestWiki(t)
+	if err := w.Edit("history/x", []byte("content")); err == nil {
+		t.Fatal("expected Edit of a reserved path to fail")
+	}
+	if _, err := os.Stat(filepath.Join(w.Path, "history", "x.md")); !os.IsNotExist(err) {
+		t.Errorf("Edit must not create a file under a reserved name, stat err = %v", err)
+	}
+}
+
+func TestRemoveRejectsReservedNames(t *testing.T) {
+	w := newTestWiki(t)
+	if err := w.Remove("api/foo"); err == nil {
+		t.Fatal("expected Remove of a reserved path to fail")
+	}
+}
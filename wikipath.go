@@ -0,0 +1,117 @@
+package main
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ReservedNames are path segments that can't be used as page titles because
+// they collide with built-in routes.
+var ReservedNames = map[string]bool{
+	"edit": true,
+	"api": true,
+	"history": true,
+	"diff": true,
+	"search": true,
+	"_pages": true,
+	"_new": true,
+}
+
+// canonicalExt is the extension pages are stored under on disk.
+const canonicalExt = ".md"
+
+// UserTitleToWebPath converts a title as typed by a user (e.g. into a "new
+// page" form) into the canonical web path used in URLs and links. Runs of
+// whitespace collapse to a single dash, any dash already in the title is
+// doubled so it survives the round trip back to WebPathToUserTitle, and
+// reserved URL characters are percent-encoded. So "2000-01-02 Meeting
+// record" becomes "2000--01--02-Meeting-record".
+func UserTitleToWebPath(title string) string {
+	segments := strings.Split(strings.Trim(title, "/"), "/")
+	for ix, seg := range segments {
+		seg = strings.ReplaceAll(seg, "-", "--")
+		seg = strings.Join(strings.Fields(seg), "-")
+		segments[ix] = url.PathEscape(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// WebPathToUserTitle converts a web path back into a human-readable title,
+// the inverse of UserTitleToWebPath: a doubled dash un-escapes to a single
+// literal dash, and any other single dash - the whitespace-collapsing kind,
+// including single dashes in legacy on-disk file names that predate this
+// escaping - becomes a space.
+func WebPathToUserTitle(p string) string {
+	segments := strings.Split(strings.Trim(p, "/"), "/")
+	for ix, seg := range segments {
+		if unescaped, err := url.PathUnescape(seg); err == nil {
+			seg = unescaped
+		}
+		segments[ix] = undashSegment(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+// undashSegment turns "--" into a literal "-" and any other "-" into a
+// space. Byte-wise scanning is safe here: "-" is ASCII 0x2D, which UTF-8
+// never produces as part of a multi-byte rune's continuation bytes.
+func undashSegment(seg string) string {
+	var b strings.Builder
+	for i := 0; i < len(seg); i++ {
+		if seg[i] != '-' {
+			b.WriteByte(seg[i])
+			continue
+		}
+		if i+1 < len(seg) && seg[i+1] == '-' {
+			b.WriteByte('-')
+			i++
+			continue
+		}
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// WebPathToGitPath converts a web path into the relative on-disk path used to
+// store the page, appending the canonical ".md" extension when it's missing.
+func WebPathToGitPath(p string) string {
+	clean := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if clean == "." {
+		clean = ""
+	}
+	if clean != "" && !strings.HasSuffix(clean, canonicalExt) {
+		clean += canonicalExt
+	}
+	return clean
+}
+
+// SameFilename reports whether two on-disk file names refer to the same
+// page, treating dashes and spaces as equivalent and ignoring the canonical
+// extension.
+func SameFilename(a, b string) bool {
+	return normalizeFilename(a) == normalizeFilename(b)
+}
+
+func normalizeFilename(name string) string {
+	name = strings.TrimSuffix(name, canonicalExt)
+	name = strings.ToLower(strings.ReplaceAll(name, "-", " "))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// IsReserved reports whether path is unusable as a page path: it has an
+// empty segment (e.g. from a doubled slash), a segment starting with a dot,
+// or a segment in ReservedNames. The root path ("" or "/") is never
+// reserved.
+func IsReserved(p string) bool {
+	trimmed := strings.Trim(p, "/")
+	if trimmed == "" {
+		return false
+	}
+	for _, seg := range strings.Split(trimmed, "/") {
+		if seg == "" || strings.HasPrefix(seg, ".") || ReservedNames[strings.ToLower(seg)] {
+			return true
+		}
+	}
+	return false
+}
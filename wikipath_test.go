@@ -0,0 +1,115 @@
+package main
+
+import "testing"
+
+func TestUserTitleToWebPath(t *testing.T) {
+	cases := []struct {
+		title string
+		want string
+	}{
+		{"2000-01-02 Meeting record", "2000--01--02-Meeting-record"},
+		{"  leading and trailing  ", "leading-and-trailing"},
+		{"café notes", "caf%C3%A9-notes"},
+		{"Projects/Q3 Plan", "Projects/Q3-Plan"},
+		{"already-dashed", "already--dashed"},
+	}
+	for _, c := range cases {
+		if got := UserTitleToWebPath(c.title); got != c.want {
+			t.Errorf("UserTitleToWebPath(%q) = %q, want %q", c.title, got, c.want)
+		}
+	}
+}
+
+func TestWebPathToUserTitle(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"2000--01--02-Meeting-record", "2000-01-02 Meeting record"},
+		{"caf%C3%A9-notes", "café notes"},
+		{"Projects/Q3-Plan", "Projects/Q3 Plan"},
+		{"Foo%2FBar", "Foo/Bar"},
+		{"Meeting-record", "Meeting record"},
+	}
+	for _, c := range cases {
+		if got := WebPathToUserTitle(c.path); got != c.want {
+			t.Errorf("WebPathToUserTitle(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestUserTitleToWebPathRoundTripsLiteralDashes(t *testing.T) {
+	titles := []string{
+		"2000-01-02 Meeting record",
+		"already-dashed",
+		"some-thing with-dashes embedded-here",
+	}
+	for _, title := range titles {
+		webPath := UserTitleToWebPath(title)
+		if got := WebPathToUserTitle(webPath); got != title {
+			t.Errorf("round trip of %q through web path %q = %q, want %q", title, webPath, got, title)
+		}
+	}
+}
+
+func TestWebPathToGitPath(t *testing.T) {
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"2000-01-02-Meeting-record", "2000-01-02-Meeting-record.md"},
+		{"Projects/Q3-Plan", "Projects/Q3-Plan.md"},
+		{"already.md", "already.md"},
+		{"", ""},
+		{"/", ""},
+	}
+	for _, c := range cases {
+		if got := WebPathToGitPath(c.path); got != c.want {
+			t.Errorf("WebPathToGitPath(%q) = %q, want %q", c.path, got, c.want)
+		}
+	}
+}
+
+func TestSameFilename(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"Meeting-record.md", "Meeting record.md", true},
+		{"Meeting-record.md", "meeting-record.md", true},
+		{"Meeting-record.md", "Other record.md", false},
+		{"Q3-Plan.md", "Q3  Plan.md", true},
+	}
+	for _, c := range cases {
+		if got := SameFilename(c.a, c.b); got != c.want {
+			t.Errorf("SameFilename(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestIsReserved(t *testing.T) {
+	cases := []struct {
+		path string
+		want bool
+	}{
+		{"", false},
+		{"/", false},
+		{"Home", false},
+		{"edit", true},
+		{"Edit", true},
+		{"api/foo", true},
+		{"history", true},
+		{"search", true},
+		{"_pages", true},
+		{"_new", true},
+		{"foo//bar", true},
+		{".hidden", true},
+		{"foo/.hidden", true},
+		{"Meeting-record", false},
+	}
+	for _, c := range cases {
+		if got := IsReserved(c.path); got != c.want {
+			t.Errorf("IsReserved(%q) = %v, want %v", c.path, got, c.want)
+		}
+	}
+}